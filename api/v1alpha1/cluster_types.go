@@ -36,10 +36,43 @@ type ClusterSpec struct {
 type ClusterStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+
+	// Leader is the name of the pod currently acting as leader, empty when
+	// the cluster has no leader.
+	// +optional
+	Leader string `json:"leader,omitempty"`
+
+	// Conditions represent the latest available observations of the cluster's state.
+	// The Degraded condition is reported today, more types will follow with the
+	// status updater.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKeys=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
+// Condition types reported in ClusterStatus.Conditions.
+const (
+	// ConditionDegraded is True when the last reconcile failed with an error
+	// that will not go away without a spec change.
+	ConditionDegraded = "Degraded"
+)
+
+// Reasons used by the cluster conditions. These are part of the API and must
+// not be renamed once released.
+const (
+	// ReasonReconcileError means the last reconcile returned an error.
+	ReasonReconcileError = "ReconcileError"
+	// ReasonReconcileSuccess means the last reconcile succeeded.
+	ReasonReconcileSuccess = "ReconcileSuccess"
+)
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Leader",type="string",JSONPath=".status.leader",description="The pod name of the current leader"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // Cluster is the Schema for the clusters API
 type Cluster struct {
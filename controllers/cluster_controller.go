@@ -18,19 +18,80 @@ package controllers
 
 import (
 	"context"
+	"strings"
+	"time"
 
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
 
 	mysqlv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
 )
 
+// operatorAnnotationPrefix is the prefix of the annotations the operator acts
+// on, such as the switchover and backup-now triggers.
+const operatorAnnotationPrefix = "mysql.radondb.com/"
+
+// clusterPredicate filters the events of the Cluster watch. Status writes do
+// not bump metadata.generation, so only spec changes and changes to the
+// operator's own annotations requeue the Cluster.
+var clusterPredicate = predicate.Or(
+	predicate.GenerationChangedPredicate{},
+	predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld == nil || e.ObjectNew == nil {
+				return false
+			}
+			return !equality.Semantic.DeepEqual(
+				operatorAnnotations(e.ObjectOld.GetAnnotations()),
+				operatorAnnotations(e.ObjectNew.GetAnnotations()),
+			)
+		},
+	},
+)
+
+// operatorAnnotations returns the annotations carrying operatorAnnotationPrefix.
+func operatorAnnotations(annotations map[string]string) map[string]string {
+	filtered := make(map[string]string)
+	for k, v := range annotations {
+		if strings.HasPrefix(k, operatorAnnotationPrefix) {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
+
+// defaultInvalidSpecRequeueDelay is used when
+// ClusterReconciler.InvalidSpecRequeueDelay is not set.
+const defaultInvalidSpecRequeueDelay = 10 * time.Minute
+
 // ClusterReconciler reconciles a Cluster object
 type ClusterReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// RateLimiter controls how fast a Cluster whose sync failed is retried.
+	// The controller-runtime default is used when nil.
+	RateLimiter ratelimiter.RateLimiter
+	// InvalidSpecRequeueDelay is how long to wait before retrying a Cluster
+	// whose sync failed with a permanent error, see isPermanentError.
+	// Defaults to 10 minutes when zero.
+	InvalidSpecRequeueDelay time.Duration
+	// Tracker, when set, records running reconciles for the health check.
+	Tracker *ReconcileTracker
+	// Images holds the operator-level image prefix and per-role overrides
+	// that the container builders resolve their images through.
+	Images utils.ImageConfig
 }
 
 //+kubebuilder:rbac:groups=mysql.radondb.com,resources=clusters,verbs=get;list;watch;create;update;patch;delete
@@ -47,16 +108,90 @@ type ClusterReconciler struct {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.8.3/pkg/reconcile
 func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
+	log := log.FromContext(ctx)
+
+	if r.Tracker != nil {
+		defer r.Tracker.Begin(req.NamespacedName)()
+	}
+
+	cluster := &mysqlv1alpha1.Cluster{}
+	if err := r.Get(ctx, req.NamespacedName, cluster); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
 
+	err := r.sync(ctx, cluster)
+	if err != nil && isPermanentError(err) {
+		log.Error(err, "cluster spec cannot be applied, waiting for it to change",
+			"requeueAfter", r.invalidSpecRequeueDelay())
+	}
+	if serr := r.updateDegradedCondition(ctx, cluster, err); serr != nil {
+		// Failing to record a permanent error must not turn it into a fast retry.
+		if err == nil || !isPermanentError(err) {
+			return ctrl.Result{}, serr
+		}
+		log.Error(serr, "failed to update the Degraded condition")
+	}
+	return resultForError(err, r.invalidSpecRequeueDelay())
+}
+
+// sync moves the actual state of the cluster towards its spec.
+func (r *ClusterReconciler) sync(ctx context.Context, cluster *mysqlv1alpha1.Cluster) error {
 	// your logic here
 
-	return ctrl.Result{}, nil
+	return nil
+}
+
+// updateDegradedCondition sets the Degraded condition to True when the sync
+// failed with a permanent error, and back to False once a sync succeeds.
+// Transient errors leave it untouched. The status is only written when the
+// condition actually changes.
+func (r *ClusterReconciler) updateDegradedCondition(ctx context.Context, cluster *mysqlv1alpha1.Cluster, syncErr error) error {
+	conditions := make([]metav1.Condition, len(cluster.Status.Conditions))
+	copy(conditions, cluster.Status.Conditions)
+
+	switch {
+	case syncErr == nil:
+		if !meta.IsStatusConditionTrue(conditions, mysqlv1alpha1.ConditionDegraded) {
+			return nil
+		}
+		meta.SetStatusCondition(&conditions, metav1.Condition{
+			Type:               mysqlv1alpha1.ConditionDegraded,
+			Status:             metav1.ConditionFalse,
+			Reason:             mysqlv1alpha1.ReasonReconcileSuccess,
+			ObservedGeneration: cluster.Generation,
+		})
+	case isPermanentError(syncErr):
+		meta.SetStatusCondition(&conditions, metav1.Condition{
+			Type:               mysqlv1alpha1.ConditionDegraded,
+			Status:             metav1.ConditionTrue,
+			Reason:             mysqlv1alpha1.ReasonReconcileError,
+			Message:            truncateMessage(syncErr.Error(), maxConditionMessageLength),
+			ObservedGeneration: cluster.Generation,
+		})
+	default:
+		return nil
+	}
+
+	if equality.Semantic.DeepEqual(conditions, cluster.Status.Conditions) {
+		return nil
+	}
+	cluster.Status.Conditions = conditions
+	return r.Status().Update(ctx, cluster)
+}
+
+// invalidSpecRequeueDelay returns InvalidSpecRequeueDelay, or the default
+// when it is not set.
+func (r *ClusterReconciler) invalidSpecRequeueDelay() time.Duration {
+	if r.InvalidSpecRequeueDelay <= 0 {
+		return defaultInvalidSpecRequeueDelay
+	}
+	return r.InvalidSpecRequeueDelay
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&mysqlv1alpha1.Cluster{}).
+		For(&mysqlv1alpha1.Cluster{}, builder.WithPredicates(clusterPredicate)).
+		WithOptions(controller.Options{RateLimiter: r.RateLimiter}).
 		Complete(r)
 }
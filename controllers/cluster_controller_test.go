@@ -0,0 +1,146 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	mysqlv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+)
+
+var _ = Describe("Cluster event filter", func() {
+	var old *mysqlv1alpha1.Cluster
+
+	BeforeEach(func() {
+		old = &mysqlv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "sample",
+				Namespace:  "default",
+				Generation: 1,
+			},
+		}
+	})
+
+	It("ignores status-only updates", func() {
+		updated := old.DeepCopy()
+		updated.Status.Leader = "sample-mysql-0"
+		Expect(clusterPredicate.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: updated})).To(BeFalse())
+	})
+
+	It("passes spec updates", func() {
+		updated := old.DeepCopy()
+		updated.Spec.Foo = "bar"
+		updated.Generation = 2
+		Expect(clusterPredicate.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: updated})).To(BeTrue())
+	})
+
+	It("passes updates of the operator's annotations", func() {
+		updated := old.DeepCopy()
+		updated.Annotations = map[string]string{"mysql.radondb.com/test": "true"}
+		Expect(clusterPredicate.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: updated})).To(BeTrue())
+
+		removed := updated.DeepCopy()
+		removed.Annotations = nil
+		Expect(clusterPredicate.Update(event.UpdateEvent{ObjectOld: updated, ObjectNew: removed})).To(BeTrue())
+	})
+
+	It("ignores updates of unrelated annotations", func() {
+		old.Annotations = map[string]string{"mysql.radondb.com/test": "true"}
+		updated := old.DeepCopy()
+		updated.Annotations["argocd.argoproj.io/tracking-id"] = "mysql:mysql.radondb.com/Cluster:default/sample"
+		updated.Annotations["kubectl.kubernetes.io/last-applied-configuration"] = "{}"
+		Expect(clusterPredicate.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: updated})).To(BeFalse())
+	})
+})
+
+var _ = Describe("Degraded condition", func() {
+	var (
+		ctx     context.Context
+		cluster *mysqlv1alpha1.Cluster
+		r       *ClusterReconciler
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		cluster = &mysqlv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "sample",
+				Namespace:  "default",
+				Generation: 3,
+			},
+		}
+		s := runtime.NewScheme()
+		Expect(mysqlv1alpha1.AddToScheme(s)).To(Succeed())
+		r = &ClusterReconciler{
+			Client: fake.NewClientBuilder().WithScheme(s).WithObjects(cluster.DeepCopy()).Build(),
+			Scheme: s,
+		}
+	})
+
+	fetch := func() *mysqlv1alpha1.Cluster {
+		got := &mysqlv1alpha1.Cluster{}
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(cluster), got)).To(Succeed())
+		return got
+	}
+
+	It("defaults the requeue delay for permanent errors", func() {
+		Expect(r.invalidSpecRequeueDelay()).To(Equal(10 * time.Minute))
+		r.InvalidSpecRequeueDelay = time.Minute
+		Expect(r.invalidSpecRequeueDelay()).To(Equal(time.Minute))
+	})
+
+	It("sets Degraded on a permanent error and clears it after a successful sync", func() {
+		Expect(r.updateDegradedCondition(ctx, fetch(), newValidationError("replicas 4 is not supported"))).To(Succeed())
+
+		cond := meta.FindStatusCondition(fetch().Status.Conditions, mysqlv1alpha1.ConditionDegraded)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+		Expect(cond.Reason).To(Equal(mysqlv1alpha1.ReasonReconcileError))
+		Expect(cond.Message).To(Equal("replicas 4 is not supported"))
+		Expect(cond.ObservedGeneration).To(Equal(int64(3)))
+
+		Expect(r.updateDegradedCondition(ctx, fetch(), nil)).To(Succeed())
+
+		cond = meta.FindStatusCondition(fetch().Status.Conditions, mysqlv1alpha1.ConditionDegraded)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+		Expect(cond.Reason).To(Equal(mysqlv1alpha1.ReasonReconcileSuccess))
+	})
+
+	It("leaves the status alone on transient errors", func() {
+		gr := schema.GroupResource{Group: "apps", Resource: "statefulsets"}
+		conflict := apierrors.NewConflict(gr, "sample-mysql", fmt.Errorf("modified"))
+		Expect(r.updateDegradedCondition(ctx, fetch(), conflict)).To(Succeed())
+		Expect(fetch().Status.Conditions).To(BeEmpty())
+
+		Expect(r.updateDegradedCondition(ctx, fetch(), nil)).To(Succeed())
+		Expect(fetch().Status.Conditions).To(BeEmpty())
+	})
+})
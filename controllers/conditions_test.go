@@ -0,0 +1,99 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mysqlv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+)
+
+var _ = Describe("Cluster conditions", func() {
+	var (
+		cluster    *mysqlv1alpha1.Cluster
+		transition metav1.Time
+	)
+
+	BeforeEach(func() {
+		// Whole seconds, as metav1.Time serializes with second precision.
+		transition = metav1.NewTime(time.Date(2021, 6, 1, 8, 30, 0, 0, time.UTC))
+		cluster = &mysqlv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default", Generation: 2},
+			Status: mysqlv1alpha1.ClusterStatus{
+				Leader: "sample-mysql-0",
+				Conditions: []metav1.Condition{{
+					Type:               mysqlv1alpha1.ConditionDegraded,
+					Status:             metav1.ConditionTrue,
+					Reason:             mysqlv1alpha1.ReasonReconcileError,
+					Message:            "replicas 4 is not supported",
+					ObservedGeneration: 2,
+					LastTransitionTime: transition,
+				}},
+			},
+		}
+	})
+
+	It("survives DeepCopy without sharing the slice", func() {
+		copied := cluster.DeepCopy()
+		Expect(copied.Status).To(Equal(cluster.Status))
+
+		copied.Status.Conditions[0].Message = "changed"
+		Expect(cluster.Status.Conditions[0].Message).To(Equal("replicas 4 is not supported"))
+	})
+
+	It("round-trips through JSON", func() {
+		data, err := json.Marshal(cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		decoded := &mysqlv1alpha1.Cluster{}
+		Expect(json.Unmarshal(data, decoded)).To(Succeed())
+		Expect(decoded.Status.Leader).To(Equal(cluster.Status.Leader))
+		Expect(decoded.Status.Conditions).To(HaveLen(1))
+		Expect(decoded.Status.Conditions[0].LastTransitionTime.Equal(&transition)).To(BeTrue())
+		decoded.Status.Conditions[0].LastTransitionTime = transition
+		Expect(decoded.Status.Conditions).To(Equal(cluster.Status.Conditions))
+	})
+
+	It("only moves LastTransitionTime when the status changes", func() {
+		meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+			Type:               mysqlv1alpha1.ConditionDegraded,
+			Status:             metav1.ConditionTrue,
+			Reason:             mysqlv1alpha1.ReasonReconcileError,
+			Message:            "replicas 6 is not supported",
+			ObservedGeneration: 3,
+		})
+		cond := meta.FindStatusCondition(cluster.Status.Conditions, mysqlv1alpha1.ConditionDegraded)
+		Expect(cond.LastTransitionTime).To(Equal(transition))
+		Expect(cond.Message).To(Equal("replicas 6 is not supported"))
+		Expect(cond.ObservedGeneration).To(Equal(int64(3)))
+
+		meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+			Type:               mysqlv1alpha1.ConditionDegraded,
+			Status:             metav1.ConditionFalse,
+			Reason:             mysqlv1alpha1.ReasonReconcileSuccess,
+			ObservedGeneration: 4,
+		})
+		cond = meta.FindStatusCondition(cluster.Status.Conditions, mysqlv1alpha1.ConditionDegraded)
+		Expect(cond.LastTransitionTime.After(transition.Time)).To(BeTrue())
+	})
+})
@@ -0,0 +1,85 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// maxConditionMessageLength is the maxLength of metav1.Condition.Message in
+// the CRD schema.
+const maxConditionMessageLength = 32768
+
+// validationError is returned when the Cluster spec cannot be applied as is.
+// Retrying will not help until the user edits the spec.
+type validationError struct {
+	msg string
+}
+
+func (e *validationError) Error() string {
+	return e.msg
+}
+
+// newValidationError returns a validationError with a formatted message.
+func newValidationError(format string, args ...interface{}) error {
+	return &validationError{msg: fmt.Sprintf(format, args...)}
+}
+
+// isPermanentError reports whether err will not fix itself on retry: our own
+// validation errors and requests rejected by the API server as invalid.
+// Everything else, such as conflicts and timeouts, is treated as transient.
+func isPermanentError(err error) bool {
+	var verr *validationError
+	if errors.As(err, &verr) {
+		return true
+	}
+	return apierrors.IsInvalid(err) || apierrors.IsBadRequest(err)
+}
+
+// resultForError maps a sync error to the reconcile result. Transient errors
+// are returned so the workqueue retries them with its rate limiter. Permanent
+// errors are swallowed and the Cluster is requeued after delay, so they do not
+// keep hammering the API server.
+func resultForError(err error, delay time.Duration) (ctrl.Result, error) {
+	if err == nil {
+		return ctrl.Result{}, nil
+	}
+	if isPermanentError(err) {
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+	return ctrl.Result{}, err
+}
+
+// truncateMessage shortens msg to at most max bytes, cutting on a rune
+// boundary and marking the cut with "...".
+func truncateMessage(msg string, max int) string {
+	const ellipsis = "..."
+	if len(msg) <= max {
+		return msg
+	}
+	cut := max - len(ellipsis)
+	for cut > 0 && !utf8.RuneStart(msg[cut]) {
+		cut--
+	}
+	return msg[:cut] + ellipsis
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var _ = Describe("Sync error classification", func() {
+	gr := schema.GroupResource{Group: "apps", Resource: "statefulsets"}
+	gk := schema.GroupKind{Group: "apps", Kind: "StatefulSet"}
+
+	It("treats invalid and bad requests as permanent", func() {
+		invalid := apierrors.NewInvalid(gk, "sample-mysql", field.ErrorList{
+			field.Invalid(field.NewPath("spec", "replicas"), 4, "unsupported"),
+		})
+		Expect(isPermanentError(invalid)).To(BeTrue())
+		Expect(isPermanentError(apierrors.NewBadRequest("bad"))).To(BeTrue())
+	})
+
+	It("treats validation errors as permanent, even when wrapped", func() {
+		err := newValidationError("replicas %d is not supported", 4)
+		Expect(isPermanentError(err)).To(BeTrue())
+		Expect(isPermanentError(fmt.Errorf("sync statefulset: %w", err))).To(BeTrue())
+	})
+
+	It("treats conflicts and timeouts as transient", func() {
+		Expect(isPermanentError(apierrors.NewConflict(gr, "sample-mysql", fmt.Errorf("modified")))).To(BeFalse())
+		Expect(isPermanentError(apierrors.NewServerTimeout(gr, "update", 1))).To(BeFalse())
+		Expect(isPermanentError(fmt.Errorf("connection refused"))).To(BeFalse())
+	})
+
+	It("maps errors to reconcile results", func() {
+		res, err := resultForError(nil, time.Minute)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(ctrl.Result{}))
+
+		res, err = resultForError(newValidationError("invalid"), time.Minute)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(ctrl.Result{RequeueAfter: time.Minute}))
+
+		conflict := apierrors.NewConflict(gr, "sample-mysql", fmt.Errorf("modified"))
+		res, err = resultForError(conflict, time.Minute)
+		Expect(err).To(Equal(conflict))
+		Expect(res).To(Equal(ctrl.Result{}))
+	})
+})
+
+var _ = Describe("truncateMessage", func() {
+	It("keeps short messages", func() {
+		Expect(truncateMessage("replicas 4 is not supported", 64)).To(Equal("replicas 4 is not supported"))
+	})
+
+	It("cuts long messages to the limit", func() {
+		msg := truncateMessage(strings.Repeat("a", maxConditionMessageLength+100), maxConditionMessageLength)
+		Expect(msg).To(HaveLen(maxConditionMessageLength))
+		Expect(msg).To(HaveSuffix("..."))
+	})
+
+	It("does not split multi-byte runes", func() {
+		msg := truncateMessage(strings.Repeat("é", 10), 8)
+		Expect(utf8.ValidString(msg)).To(BeTrue())
+		Expect(len(msg)).To(BeNumerically("<=", 8))
+		Expect(msg).To(Equal("éé..."))
+	})
+})
@@ -0,0 +1,94 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ReconcileTracker records when each in-flight reconcile started, so that a
+// reconcile stuck for longer than Threshold marks the operator unhealthy.
+type ReconcileTracker struct {
+	// Threshold is how long a single reconcile may run before it is
+	// considered stalled.
+	Threshold time.Duration
+
+	mu       sync.Mutex
+	inflight map[types.NamespacedName]time.Time
+	now      func() time.Time
+}
+
+// NewReconcileTracker returns a ReconcileTracker with the given threshold.
+func NewReconcileTracker(threshold time.Duration) *ReconcileTracker {
+	return &ReconcileTracker{
+		Threshold: threshold,
+		inflight:  make(map[types.NamespacedName]time.Time),
+		now:       time.Now,
+	}
+}
+
+// Begin marks the reconcile of key as started. The returned function marks it
+// as finished and is meant to be deferred.
+func (t *ReconcileTracker) Begin(key types.NamespacedName) func() {
+	t.mu.Lock()
+	t.inflight[key] = t.now()
+	t.mu.Unlock()
+
+	return func() {
+		t.mu.Lock()
+		delete(t.inflight, key)
+		t.mu.Unlock()
+	}
+}
+
+// Check implements healthz.Checker. It fails when any reconcile has been
+// running for longer than Threshold.
+func (t *ReconcileTracker) Check(_ *http.Request) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	for key, started := range t.inflight {
+		if elapsed := now.Sub(started); elapsed > t.Threshold {
+			return fmt.Errorf("reconcile of cluster %s stalled for %s", key, elapsed.Round(time.Second))
+		}
+	}
+	return nil
+}
+
+// CheckLoopbackAddr returns an error unless addr is a host:port whose host is
+// localhost or a loopback IP. It guards endpoints that have no authentication,
+// such as pprof.
+func CheckLoopbackAddr(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("%q is not a localhost or loopback address", addr)
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("ReconcileTracker", func() {
+	var (
+		tracker *ReconcileTracker
+		now     time.Time
+		key     = types.NamespacedName{Namespace: "default", Name: "sample"}
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+		tracker = NewReconcileTracker(time.Hour)
+		tracker.now = func() time.Time { return now }
+	})
+
+	It("is healthy when nothing is running", func() {
+		Expect(tracker.Check(nil)).To(Succeed())
+	})
+
+	It("is healthy while a reconcile is within the threshold", func() {
+		tracker.Begin(key)
+		now = now.Add(59 * time.Minute)
+		Expect(tracker.Check(nil)).To(Succeed())
+	})
+
+	It("reports a reconcile running beyond the threshold", func() {
+		tracker.Begin(key)
+		now = now.Add(61 * time.Minute)
+		err := tracker.Check(nil)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("default/sample"))
+	})
+
+	It("recovers once the stalled reconcile finishes", func() {
+		done := tracker.Begin(key)
+		now = now.Add(2 * time.Hour)
+		Expect(tracker.Check(nil)).NotTo(Succeed())
+		done()
+		Expect(tracker.Check(nil)).To(Succeed())
+	})
+})
+
+var _ = Describe("CheckLoopbackAddr", func() {
+	table.DescribeTable("accepts only localhost and loopback addresses",
+		func(addr string, ok bool) {
+			if ok {
+				Expect(CheckLoopbackAddr(addr)).To(Succeed())
+			} else {
+				Expect(CheckLoopbackAddr(addr)).NotTo(Succeed())
+			}
+		},
+		table.Entry("IPv4 loopback", "127.0.0.1:6060", true),
+		table.Entry("other IPv4 loopback", "127.0.0.2:6060", true),
+		table.Entry("IPv6 loopback", "[::1]:6060", true),
+		table.Entry("localhost", "localhost:6060", true),
+		table.Entry("empty host", ":6060", false),
+		table.Entry("all IPv4 interfaces", "0.0.0.0:6060", false),
+		table.Entry("all IPv6 interfaces", "[::]:6060", false),
+		table.Entry("pod IP", "10.244.1.17:6060", false),
+		table.Entry("hostname", "operator.example.com:6060", false),
+		table.Entry("no port", "127.0.0.1", false),
+	)
+})
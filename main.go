@@ -17,22 +17,30 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"net/http"
+	"net/http/pprof"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"golang.org/x/time/rate"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	mysqlv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
 	"github.com/radondb/radondb-mysql-kubernetes/controllers"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -52,11 +60,34 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var reconcileBaseDelay time.Duration
+	var reconcileMaxDelay time.Duration
+	var invalidSpecRequeueDelay time.Duration
+	var reconcileStallThreshold time.Duration
+	var enablePprof bool
+	var pprofAddr string
+	var imagePrefix string
+	var imageOverrides string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.DurationVar(&reconcileBaseDelay, "reconcile-base-delay", 5*time.Millisecond,
+		"The initial delay before retrying a Cluster whose sync failed. It doubles on each consecutive failure.")
+	flag.DurationVar(&reconcileMaxDelay, "reconcile-max-delay", 1000*time.Second,
+		"The maximum delay before retrying a Cluster whose sync failed.")
+	flag.DurationVar(&invalidSpecRequeueDelay, "invalid-spec-requeue-delay", 10*time.Minute,
+		"The delay before retrying a Cluster whose spec was rejected as invalid.")
+	flag.DurationVar(&reconcileStallThreshold, "reconcile-stall-threshold", 3*time.Hour,
+		"How long a single reconcile may run before the health check reports the operator as unhealthy.")
+	flag.BoolVar(&enablePprof, "enable-pprof", false, "Enable the net/http/pprof endpoints.")
+	flag.StringVar(&pprofAddr, "pprof-bind-address", "127.0.0.1:6060",
+		"The address the pprof endpoints bind to. The endpoints are unauthenticated, so only localhost or a loopback IP is accepted.")
+	flag.StringVar(&imagePrefix, "image-prefix", "",
+		"Registry prefix that replaces the registry of every image the operator deploys, e.g. registry.local:5000/mirror.")
+	flag.StringVar(&imageOverrides, "image-overrides", "",
+		"Comma separated role=image pairs overriding the image of a container role (mysql, xenon, sidecar, busybox, exporter).")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -65,6 +96,34 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if reconcileBaseDelay <= 0 || reconcileBaseDelay > reconcileMaxDelay {
+		setupLog.Error(errors.New("invalid reconcile backoff"),
+			"--reconcile-base-delay must be positive and not greater than --reconcile-max-delay",
+			"base", reconcileBaseDelay, "max", reconcileMaxDelay)
+		os.Exit(1)
+	}
+	if invalidSpecRequeueDelay <= 0 {
+		setupLog.Error(errors.New("invalid requeue delay"),
+			"--invalid-spec-requeue-delay must be positive", "delay", invalidSpecRequeueDelay)
+		os.Exit(1)
+	}
+	if reconcileStallThreshold <= 0 {
+		setupLog.Error(errors.New("invalid stall threshold"),
+			"--reconcile-stall-threshold must be positive", "threshold", reconcileStallThreshold)
+		os.Exit(1)
+	}
+	overrides, err := utils.ParseImageOverrides(imageOverrides)
+	if err != nil {
+		setupLog.Error(err, "invalid --image-overrides")
+		os.Exit(1)
+	}
+	if enablePprof {
+		if err := controllers.CheckLoopbackAddr(pprofAddr); err != nil {
+			setupLog.Error(err, "invalid --pprof-bind-address")
+			os.Exit(1)
+		}
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
@@ -78,9 +137,21 @@ func main() {
 		os.Exit(1)
 	}
 
+	tracker := controllers.NewReconcileTracker(reconcileStallThreshold)
 	if err = (&controllers.ClusterReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
+		// Same as workqueue.DefaultControllerRateLimiter, with a configurable per-item backoff.
+		RateLimiter: workqueue.NewMaxOfRateLimiter(
+			workqueue.NewItemExponentialFailureRateLimiter(reconcileBaseDelay, reconcileMaxDelay),
+			&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+		),
+		InvalidSpecRequeueDelay: invalidSpecRequeueDelay,
+		Tracker:                 tracker,
+		Images: utils.ImageConfig{
+			Prefix:    imagePrefix,
+			Overrides: overrides,
+		},
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Cluster")
 		os.Exit(1)
@@ -91,10 +162,25 @@ func main() {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
 	}
+	if err := mgr.AddHealthzCheck("reconcile", tracker.Check); err != nil {
+		setupLog.Error(err, "unable to set up reconcile health check")
+		os.Exit(1)
+	}
 	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	if err := mgr.AddReadyzCheck("reconcile", tracker.Check); err != nil {
+		setupLog.Error(err, "unable to set up reconcile ready check")
+		os.Exit(1)
+	}
+
+	if enablePprof {
+		if err := mgr.Add(pprofServer{addr: pprofAddr}); err != nil {
+			setupLog.Error(err, "unable to set up pprof server")
+			os.Exit(1)
+		}
+	}
 
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
@@ -102,3 +188,34 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// pprofServer serves the net/http/pprof endpoints until the manager stops.
+// It runs on every replica, not only on the elected leader.
+type pprofServer struct {
+	addr string
+}
+
+func (p pprofServer) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	srv := &http.Server{Addr: p.addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Shutdown(context.Background())
+	}()
+
+	setupLog.Info("starting pprof server", "addr", p.addr)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (pprofServer) NeedLeaderElection() bool {
+	return false
+}
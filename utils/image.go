@@ -0,0 +1,111 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Container roles whose image can be overridden.
+const (
+	ImageMysql   = "mysql"
+	ImageXenon   = "xenon"
+	ImageSidecar = "sidecar"
+	ImageBusybox = "busybox"
+	ImageMetrics = "exporter"
+)
+
+var imageRoles = map[string]bool{
+	ImageMysql:   true,
+	ImageXenon:   true,
+	ImageSidecar: true,
+	ImageBusybox: true,
+	ImageMetrics: true,
+}
+
+// ImageConfig holds the operator-level image settings for air-gapped
+// installs.
+type ImageConfig struct {
+	// Prefix replaces the registry of every image, see RewriteImagePrefix.
+	Prefix string
+	// Overrides maps a container role (mysql, xenon, sidecar, busybox,
+	// exporter) to the full image used for it. It wins over Prefix.
+	Overrides map[string]string
+}
+
+// Resolve returns the image to run for the container role, given the image
+// it would use by default.
+func (c ImageConfig) Resolve(role, image string) string {
+	if override := c.Overrides[role]; override != "" {
+		return override
+	}
+	return RewriteImagePrefix(image, c.Prefix)
+}
+
+// RewriteImagePrefix replaces the registry host of image with prefix and keeps
+// the repository path, tag and digest, which is the layout path-preserving
+// mirrors such as a Harbor proxy cache or skopeo sync produce. The first path
+// component is only treated as a registry host when it contains a "." or ":"
+// or is "localhost", so "percona/percona-server:5.7.34" and
+// "docker.io/percona/percona-server:5.7.34" both become
+// "<prefix>/percona/percona-server:5.7.34". The image is returned unchanged
+// when prefix is empty.
+func RewriteImagePrefix(image, prefix string) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" || image == "" {
+		return image
+	}
+
+	if i := strings.Index(image, "/"); i >= 0 {
+		host := image[:i]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			image = image[i+1:]
+		}
+	}
+	return prefix + "/" + image
+}
+
+// ParseImageOverrides parses a comma separated list of role=image pairs,
+// e.g. "busybox=registry.local/busybox:1.32,xenon=registry.local/xenon:v1".
+func ParseImageOverrides(s string) (map[string]string, error) {
+	overrides := make(map[string]string)
+	if strings.TrimSpace(s) == "" {
+		return overrides, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return nil, fmt.Errorf("invalid image override %q, expected role=image", pair)
+		}
+		if !imageRoles[kv[0]] {
+			return nil, fmt.Errorf("unknown image role %q, expected one of %s", kv[0], strings.Join(knownImageRoles(), ", "))
+		}
+		overrides[kv[0]] = kv[1]
+	}
+	return overrides, nil
+}
+
+func knownImageRoles() []string {
+	roles := make([]string, 0, len(imageRoles))
+	for role := range imageRoles {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
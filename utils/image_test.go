@@ -0,0 +1,112 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+const digest = "sha256:4c5a6b8f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e4d3c2b1a0f9e8d7c6b"
+
+var _ = Describe("RewriteImagePrefix", func() {
+	table.DescribeTable("rewrites the registry and keeps the repository path",
+		func(image, prefix, expected string) {
+			Expect(RewriteImagePrefix(image, prefix)).To(Equal(expected))
+		},
+		table.Entry("no prefix", "xenondb/xenon:1.1.5-alpha", "", "xenondb/xenon:1.1.5-alpha"),
+		table.Entry("empty image", "", "registry.local/mirror", ""),
+		table.Entry("official image without registry", "busybox:1.32", "registry.local/mirror", "registry.local/mirror/busybox:1.32"),
+		table.Entry("image without tag", "busybox", "registry.local/mirror", "registry.local/mirror/busybox"),
+		table.Entry("repository without registry", "prom/mysqld-exporter:v0.12.1", "registry.local/mirror",
+			"registry.local/mirror/prom/mysqld-exporter:v0.12.1"),
+		table.Entry("image with registry", "docker.io/percona/percona-server:5.7.34", "registry.local/mirror",
+			"registry.local/mirror/percona/percona-server:5.7.34"),
+		table.Entry("nested repository path", "ghcr.io/radondb/tools/mysql-sidecar:v2", "registry.local/mirror",
+			"registry.local/mirror/radondb/tools/mysql-sidecar:v2"),
+		table.Entry("registry with port", "registry.example.com:5000/radondb/mysql-sidecar:latest", "registry.local/mirror",
+			"registry.local/mirror/radondb/mysql-sidecar:latest"),
+		table.Entry("localhost registry", "localhost/busybox", "registry.local/mirror", "registry.local/mirror/busybox"),
+		table.Entry("localhost registry with port", "localhost:5000/busybox", "registry.local/mirror",
+			"registry.local/mirror/busybox"),
+		table.Entry("prefix with port", "percona/percona-server:5.7.34", "registry.local:5000/mirror",
+			"registry.local:5000/mirror/percona/percona-server:5.7.34"),
+		table.Entry("prefix with trailing slash", "busybox:1.32", "registry.local/mirror/", "registry.local/mirror/busybox:1.32"),
+		table.Entry("digest", "xenondb/xenon@"+digest, "registry.local/mirror", "registry.local/mirror/xenondb/xenon@"+digest),
+		table.Entry("tag and digest", "xenondb/xenon:1.1.5-alpha@"+digest, "registry.local/mirror",
+			"registry.local/mirror/xenondb/xenon:1.1.5-alpha@"+digest),
+		table.Entry("registry with port and digest", "registry.example.com:5000/radondb/xenon@"+digest, "registry.local:5000/mirror",
+			"registry.local:5000/mirror/radondb/xenon@"+digest),
+	)
+
+	It("keeps images with the same name in different repositories apart", func() {
+		Expect(RewriteImagePrefix("percona/percona-server:5.7.34", "registry.local")).
+			NotTo(Equal(RewriteImagePrefix("acme/percona-server:5.7.34", "registry.local")))
+	})
+})
+
+var _ = Describe("ImageConfig", func() {
+	cfg := ImageConfig{
+		Prefix: "registry.local/mirror",
+		Overrides: map[string]string{
+			ImageBusybox: "registry.local/base/busybox:1.32-patched",
+		},
+	}
+
+	It("prefers the override for the container role", func() {
+		Expect(cfg.Resolve(ImageBusybox, "busybox:1.32")).To(Equal("registry.local/base/busybox:1.32-patched"))
+	})
+
+	It("keeps applying the override after the default tag changes", func() {
+		Expect(cfg.Resolve(ImageBusybox, "busybox:1.33")).To(Equal("registry.local/base/busybox:1.32-patched"))
+	})
+
+	It("falls back to the prefix rewrite for other roles", func() {
+		Expect(cfg.Resolve(ImageXenon, "xenondb/xenon:1.1.5-alpha")).To(Equal("registry.local/mirror/xenondb/xenon:1.1.5-alpha"))
+	})
+
+	It("returns the image unchanged when nothing is configured", func() {
+		Expect(ImageConfig{}.Resolve(ImageMysql, "percona/percona-server:5.7.34")).To(Equal("percona/percona-server:5.7.34"))
+	})
+})
+
+var _ = Describe("ParseImageOverrides", func() {
+	It("parses role=image pairs", func() {
+		overrides, err := ParseImageOverrides("busybox=registry.local/busybox:1.32, xenon=registry.local:5000/xenon@" + digest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(overrides).To(Equal(map[string]string{
+			ImageBusybox: "registry.local/busybox:1.32",
+			ImageXenon:   "registry.local:5000/xenon@" + digest,
+		}))
+	})
+
+	It("accepts an empty value", func() {
+		overrides, err := ParseImageOverrides("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(overrides).To(BeEmpty())
+	})
+
+	It("rejects unknown roles and malformed pairs", func() {
+		_, err := ParseImageOverrides("proxysql=registry.local/proxysql:2")
+		Expect(err).To(MatchError(ContainSubstring("unknown image role")))
+		_, err = ParseImageOverrides("busybox")
+		Expect(err).To(HaveOccurred())
+		_, err = ParseImageOverrides("busybox=")
+		Expect(err).To(HaveOccurred())
+	})
+})